@@ -5,7 +5,9 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"container/list"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -13,8 +15,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	texttmpl "text/template"
 
 	// Blank import ensures the CLI keeps a direct dependency on golang.org/x/crypto
@@ -108,34 +113,164 @@ func flattenExtremumArgs(args []interface{}) []interface{} {
 	return flattened
 }
 
+var (
+	mapOfAnyType       = reflect.TypeOf(map[string]interface{}{})
+	stringSliceType    = reflect.TypeOf([]string{})
+	interfaceSliceType = reflect.TypeOf([]interface{}{})
+)
+
+// wrapDeterministicMapFunctions replaces every Sprig helper shaped like
+// `func(...map[string]interface{}) []string`, `func(map[string]interface{})
+// []string`, or either of those with a `[]interface{}` result (`keys` is
+// variadic, `values` takes a single map; any future helper matching one of
+// these signatures is covered too) with a variant that sorts its output, so
+// the Rust parity tests don't see Go's randomized map-iteration order.
+func wrapDeterministicMapFunctions(funcs map[string]interface{}) {
+	for name, fn := range funcs {
+		fnType := reflect.TypeOf(fn)
+		if fnType == nil || fnType.Kind() != reflect.Func || fnType.NumOut() != 1 {
+			continue
+		}
+
+		switch {
+		case fnType.IsVariadic() && fnType.NumIn() == 1 && fnType.In(0).Elem() == mapOfAnyType:
+			switch fnType.Out(0) {
+			case stringSliceType:
+				funcs[name] = wrapSortedStringsFunc(fn)
+			case interfaceSliceType:
+				funcs[name] = wrapSortedInterfacesFunc(fn)
+			}
+		case !fnType.IsVariadic() && fnType.NumIn() == 1 && fnType.In(0) == mapOfAnyType:
+			switch fnType.Out(0) {
+			case stringSliceType:
+				funcs[name] = wrapSortedStringsFuncSingle(fn)
+			case interfaceSliceType:
+				funcs[name] = wrapSortedInterfacesFuncSingle(fn)
+			}
+		}
+	}
+}
+
+func wrapSortedStringsFunc(fn interface{}) func(...map[string]interface{}) []string {
+	original := reflect.ValueOf(fn)
+	return func(dicts ...map[string]interface{}) []string {
+		out := callVariadicMapFunc(original, dicts)[0].Interface().([]string)
+		sorted := append([]string(nil), out...)
+		sort.Strings(sorted)
+		return sorted
+	}
+}
+
+func wrapSortedInterfacesFunc(fn interface{}) func(...map[string]interface{}) []interface{} {
+	original := reflect.ValueOf(fn)
+	return func(dicts ...map[string]interface{}) []interface{} {
+		out := callVariadicMapFunc(original, dicts)[0].Interface().([]interface{})
+		sorted := append([]interface{}(nil), out...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return fmt.Sprint(sorted[i]) < fmt.Sprint(sorted[j])
+		})
+		return sorted
+	}
+}
+
+// wrapSortedStringsFuncSingle is wrapSortedStringsFunc for helpers that
+// take a single map rather than a variadic list of maps and return []string.
+func wrapSortedStringsFuncSingle(fn interface{}) func(map[string]interface{}) []string {
+	original := reflect.ValueOf(fn)
+	return func(dict map[string]interface{}) []string {
+		out := original.Call([]reflect.Value{reflect.ValueOf(dict)})[0].Interface().([]string)
+		sorted := append([]string(nil), out...)
+		sort.Strings(sorted)
+		return sorted
+	}
+}
+
+// wrapSortedInterfacesFuncSingle is wrapSortedInterfacesFunc for helpers
+// like `values` that take a single map rather than a variadic list of maps.
+func wrapSortedInterfacesFuncSingle(fn interface{}) func(map[string]interface{}) []interface{} {
+	original := reflect.ValueOf(fn)
+	return func(dict map[string]interface{}) []interface{} {
+		out := original.Call([]reflect.Value{reflect.ValueOf(dict)})[0].Interface().([]interface{})
+		sorted := append([]interface{}(nil), out...)
+		sort.Slice(sorted, func(i, j int) bool {
+			return fmt.Sprint(sorted[i]) < fmt.Sprint(sorted[j])
+		})
+		return sorted
+	}
+}
+
+func callVariadicMapFunc(fn reflect.Value, dicts []map[string]interface{}) []reflect.Value {
+	args := make([]reflect.Value, len(dicts))
+	for i, d := range dicts {
+		args[i] = reflect.ValueOf(d)
+	}
+	return fn.Call(args)
+}
+
 func main() {
 	defaultCases := filepath.Join("..", "test-cases", "lithos-sprig.json")
 	casesPath := flag.String("cases", defaultCases, "path to JSON file with function cases")
 	includeSprig := flag.Bool("sprig", true, "include Sprig helper functions")
+	preserveNumbers := flag.Bool("preserve-numbers", false, "pass decoded json.Number values on as their plain string literal so 64-bit precision survives template rendering, instead of narrowing to int64/float64")
+	maxDepth := flag.Int("max-depth", 10000, "maximum nesting depth allowed in a case's args/data before rendering is refused")
+	stream := flag.Bool("stream", true, "stream case results as they complete instead of buffering the full result slice")
+	batch := flag.Bool("batch", false, "buffer all results and pretty-print them as one JSON array (legacy, implies -stream=false)")
+	parallel := flag.Int("parallel", runtime.NumCPU(), "number of worker goroutines evaluating cases concurrently in -stream mode")
+	serveFlag := flag.Bool("serve", false, "run as a long-lived line-delimited JSON-over-stdio server instead of reading -cases once and exiting")
+	templateCacheSize := flag.Int("template-cache-size", 256, "maximum number of parsed templates kept in the LRU template cache")
+	deterministic := flag.Bool("deterministic", true, "sort the output of map-returning Sprig helpers (keys, values, ...) so results don't depend on Go's randomized map iteration order")
 	flag.Parse()
 
-	if err := run(os.Stdout, *casesPath, *includeSprig); err != nil {
+	funcs := buildFuncs(*includeSprig, *deterministic)
+	cache := newTemplateCache(*templateCacheSize)
+
+	if *serveFlag {
+		if err := serve(os.Stdin, os.Stdout, funcs, *includeSprig, *preserveNumbers, *maxDepth, *deterministic, cache); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	if err := run(os.Stdout, *casesPath, funcs, *includeSprig, *preserveNumbers, *maxDepth, *stream, *batch, *parallel, *deterministic, cache); err != nil {
 		fail(err)
 	}
 }
 
-func run(output io.Writer, casesPath string, includeSprig bool) error {
+// buildFuncs constructs the Sprig function map exactly once so both the
+// one-shot and -serve code paths reuse the same funcs across every case.
+func buildFuncs(includeSprig, deterministic bool) map[string]interface{} {
+	if !includeSprig {
+		return nil
+	}
+	funcs := sprig.GenericFuncMap()
+	wrapExtremumFunctions(funcs)
+	if deterministic {
+		wrapDeterministicMapFunctions(funcs)
+	}
+	funcs["splitn"] = func(sep, text string, n int) []string {
+		return strings.SplitN(text, sep, n)
+	}
+	return funcs
+}
+
+func run(output io.Writer, casesPath string, funcs map[string]interface{}, includeSprig, preserveNumbers bool, maxDepth int, stream, batch bool, parallel int, deterministic bool, cache *templateCache) error {
 	cases, err := loadCases(casesPath)
 	if err != nil {
 		return err
 	}
 
-    var funcs map[string]interface{}
-    if includeSprig {
-        funcs = sprig.GenericFuncMap()
-        wrapExtremumFunctions(funcs)
-        funcs["splitn"] = func(sep, text string, n int) []string {
-            return strings.SplitN(text, sep, n)
-        }
-    }
+	if batch || !stream {
+		return runBatch(output, funcs, includeSprig, preserveNumbers, maxDepth, cases, deterministic, cache)
+	}
+	return runStream(output, funcs, includeSprig, preserveNumbers, maxDepth, parallel, cases, deterministic, cache)
+}
+
+// runBatch is the original, non-streaming mode: every case is evaluated and
+// held in memory before being pretty-printed as a single JSON array.
+func runBatch(output io.Writer, funcs map[string]interface{}, includeSprig, preserveNumbers bool, maxDepth int, cases []testCase, deterministic bool, cache *templateCache) error {
 	results := make([]result, 0, len(cases))
 	for _, c := range cases {
-		res, errs := evaluateCase(funcs, includeSprig, c)
+		res, errs := evaluateCase(funcs, includeSprig, c, preserveNumbers, maxDepth, deterministic, cache)
 		if errMsg := collectErrors(errs); errMsg != "" {
 			res.Error = errMsg
 		}
@@ -150,7 +285,152 @@ func run(output io.Writer, casesPath string, includeSprig bool) error {
 	return nil
 }
 
-func evaluateCase(funcs map[string]interface{}, includeSprig bool, c testCase) (result, []error) {
+// runStream evaluates cases across a worker pool of size parallel and writes
+// each result to output as soon as it's ready, so the full []result slice
+// (and every rendered template/Args/Data tree it holds) never has to exist
+// in memory at once. Results are still emitted in input order: a worker that
+// finishes case 5 before case 2 parks its result until case 2 has been
+// written.
+func runStream(output io.Writer, funcs map[string]interface{}, includeSprig, preserveNumbers bool, maxDepth, parallel int, cases []testCase, deterministic bool, cache *templateCache) error {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	type indexedResult struct {
+		index int
+		res   result
+	}
+
+	jobs := make(chan int)
+	done := make(chan indexedResult)
+
+	var workers sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for idx := range jobs {
+				res, errs := evaluateCase(funcs, includeSprig, cases[idx], preserveNumbers, maxDepth, deterministic, cache)
+				if errMsg := collectErrors(errs); errMsg != "" {
+					res.Error = errMsg
+				}
+				done <- indexedResult{index: idx, res: res}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range cases {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	if _, err := io.WriteString(output, "["); err != nil {
+		return fmt.Errorf("write opening bracket: %w", err)
+	}
+
+	pending := make(map[int]result, parallel)
+	next := 0
+	for ir := range done {
+		pending[ir.index] = ir.res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if next > 0 {
+				if _, err := io.WriteString(output, ","); err != nil {
+					return fmt.Errorf("write separator: %w", err)
+				}
+			}
+			encoded, err := json.Marshal(res)
+			if err != nil {
+				return fmt.Errorf("encode result %d: %w", next, err)
+			}
+			if _, err := output.Write(encoded); err != nil {
+				return fmt.Errorf("write result %d: %w", next, err)
+			}
+			next++
+		}
+	}
+
+	if _, err := io.WriteString(output, "]\n"); err != nil {
+		return fmt.Errorf("write closing bracket: %w", err)
+	}
+	return nil
+}
+
+// serve runs a long-lived line-delimited JSON-over-stdio server: each input
+// line is either a single testCase or a JSON array of them, and the matching
+// result (or array of results) is written back as one output line. funcs and
+// cache are built once by the caller and reused across every request, so
+// the Rust parity suite can drive thousands of cases through a single child
+// process instead of forking one per batch.
+func serve(input io.Reader, output io.Writer, funcs map[string]interface{}, includeSprig, preserveNumbers bool, maxDepth int, deterministic bool, cache *templateCache) error {
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		resp, err := handleServeRequest(line, funcs, includeSprig, preserveNumbers, maxDepth, deterministic, cache)
+		if err != nil {
+			return fmt.Errorf("handle request: %w", err)
+		}
+		if _, err := output.Write(resp); err != nil {
+			return fmt.Errorf("write response: %w", err)
+		}
+		if _, err := io.WriteString(output, "\n"); err != nil {
+			return fmt.Errorf("write response newline: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read request: %w", err)
+	}
+	return nil
+}
+
+func handleServeRequest(line []byte, funcs map[string]interface{}, includeSprig, preserveNumbers bool, maxDepth int, deterministic bool, cache *templateCache) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	if line[0] == '[' {
+		var cases []testCase
+		if err := decoder.Decode(&cases); err != nil {
+			return nil, fmt.Errorf("decode request batch: %w", err)
+		}
+		results := make([]result, len(cases))
+		for i, c := range cases {
+			res, errs := evaluateCase(funcs, includeSprig, c, preserveNumbers, maxDepth, deterministic, cache)
+			if errMsg := collectErrors(errs); errMsg != "" {
+				res.Error = errMsg
+			}
+			results[i] = res
+		}
+		return json.Marshal(results)
+	}
+
+	var c testCase
+	if err := decoder.Decode(&c); err != nil {
+		return nil, fmt.Errorf("decode request: %w", err)
+	}
+	res, errs := evaluateCase(funcs, includeSprig, c, preserveNumbers, maxDepth, deterministic, cache)
+	if errMsg := collectErrors(errs); errMsg != "" {
+		res.Error = errMsg
+	}
+	return json.Marshal(res)
+}
+
+func evaluateCase(funcs map[string]interface{}, includeSprig bool, c testCase, preserveNumbers bool, maxDepth int, deterministic bool, cache *templateCache) (result, []error) {
 	res := result{
 		Name:     c.Name,
 		Function: c.Function,
@@ -165,8 +445,14 @@ func evaluateCase(funcs map[string]interface{}, includeSprig bool, c testCase) (
 	if c.Function != "" {
 		if funcs == nil {
 			errs = append(errs, fmt.Errorf("function %q requested but Sprig helpers are disabled", c.Function))
+		} else if err := validateData(c.Args, maxDepth); err != nil {
+			errs = append(errs, fmt.Errorf("args: %w", err))
 		} else {
-			out, err := evaluate(funcs, c.Function, c.Args)
+			args := make([]interface{}, len(c.Args))
+			for i, arg := range c.Args {
+				args[i] = normalizeTemplateData(arg, preserveNumbers)
+			}
+			out, err := evaluate(funcs, c.Function, args)
 			if err != nil {
 				errs = append(errs, err)
 			} else {
@@ -176,8 +462,9 @@ func evaluateCase(funcs map[string]interface{}, includeSprig bool, c testCase) (
 	}
 
 	if c.Template != "" {
-		rendered, err := renderTemplate(c.Template, c.Data, includeSprig)
-		if err != nil {
+		if err := validateData(c.Data, maxDepth); err != nil {
+			errs = append(errs, fmt.Errorf("data: %w", err))
+		} else if rendered, err := renderTemplate(cache, c.Template, c.Data, includeSprig, preserveNumbers, deterministic); err != nil {
 			errs = append(errs, err)
 		} else {
 			renderedCopy := rendered
@@ -191,6 +478,121 @@ func evaluateCase(funcs map[string]interface{}, includeSprig bool, c testCase) (
 	return res, errs
 }
 
+// validateData walks data looking for cycles (a map, slice, or pointer that
+// transitively contains itself) and for nesting deeper than maxDepth,
+// either of which could make renderTemplate or the argument coercion path
+// loop forever or exhaust the stack on a malformed fixture.
+func validateData(data interface{}, maxDepth int) error {
+	return checkDataDepth(data, maxDepth, 0, make(map[uintptr]struct{}))
+}
+
+func checkDataDepth(data interface{}, maxDepth, depth int, visited map[uintptr]struct{}) error {
+	if depth > maxDepth {
+		return fmt.Errorf("exceeded max depth %d", maxDepth)
+	}
+
+	val := reflect.ValueOf(data)
+	if !val.IsValid() {
+		return nil
+	}
+
+	switch val.Kind() {
+	case reflect.Map:
+		if err := withVisited(val, visited, func() error {
+			for _, key := range val.MapKeys() {
+				if err := checkDataDepth(val.MapIndex(key).Interface(), maxDepth, depth+1, visited); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	case reflect.Slice:
+		if err := withVisited(val, visited, func() error {
+			for i := 0; i < val.Len(); i++ {
+				if err := checkDataDepth(val.Index(i).Interface(), maxDepth, depth+1, visited); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	case reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			if err := checkDataDepth(val.Index(i).Interface(), maxDepth, depth+1, visited); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if val.IsNil() {
+			return nil
+		}
+		return withVisited(val, visited, func() error {
+			return checkDataDepth(val.Elem().Interface(), maxDepth, depth+1, visited)
+		})
+	}
+	return nil
+}
+
+// withVisited guards walk against recursing back into an ancestor, which
+// signals a cycle rather than two sibling branches sharing the same
+// underlying map/slice/pointer. The address is released once this branch of
+// the walk returns, so shared-but-acyclic structures still pass.
+func withVisited(val reflect.Value, visited map[uintptr]struct{}, walk func() error) error {
+	ptr := val.Pointer()
+	if ptr == 0 {
+		return walk()
+	}
+	if _, seen := visited[ptr]; seen {
+		return fmt.Errorf("cycle detected in data")
+	}
+	visited[ptr] = struct{}{}
+	defer delete(visited, ptr)
+	return walk()
+}
+
+// normalizeTemplateData recursively walks decoded JSON data (as produced by
+// loadCases, which calls decoder.UseNumber()) and resolves json.Number
+// values into the concrete numeric types text/template and Sprig expect.
+// Strings and bools pass through untouched. When preserveNumbers is set, a
+// json.Number is instead reduced to its plain string literal: spf13/cast
+// (which backs Sprig's numeric helpers) parses a string via strconv, but its
+// type switch only special-cases json.Number from v1.4.0 onward, and this
+// tree has no go.mod pinning cast to that version. A plain string is
+// recognized on any cast version, so the literal survives rendering without
+// narrowing through float64 or int64.
+func normalizeTemplateData(data interface{}, preserveNumbers bool) interface{} {
+	switch v := data.(type) {
+	case json.Number:
+		if preserveNumbers {
+			return v.String()
+		}
+		if i64, err := v.Int64(); err == nil {
+			return i64
+		}
+		if f64, err := v.Float64(); err == nil {
+			return f64
+		}
+		return v.String()
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			out[key] = normalizeTemplateData(val, preserveNumbers)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = normalizeTemplateData(val, preserveNumbers)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
 func collectErrors(errs []error) string {
 	if len(errs) == 0 {
 		return ""
@@ -257,10 +659,29 @@ func evaluate(funcs map[string]interface{}, name string, args []interface{}) (in
 	return out, nil
 }
 
-func renderTemplate(tpl string, data interface{}, includeSprig bool) (string, error) {
+func renderTemplate(cache *templateCache, tpl string, data interface{}, includeSprig, preserveNumbers, deterministic bool) (string, error) {
+	parsed, err := cache.get(tpl, includeSprig, deterministic)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := parsed.Execute(&buf, normalizeTemplateData(data, preserveNumbers)); err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// buildTemplate parses tpl into a *texttmpl.Template, wiring up the Sprig
+// func map the same way renderTemplate always has. It's factored out of
+// renderTemplate so templateCache can call it only on a cache miss.
+func buildTemplate(tpl string, includeSprig, deterministic bool) (*texttmpl.Template, error) {
 	tmpl := texttmpl.New("case")
 	if includeSprig {
 		funcs := sprig.TxtFuncMap()
+		if deterministic {
+			wrapDeterministicMapFunctions(funcs)
+		}
 		funcs["splitn"] = func(sep, text string, n int) []string {
 			return strings.SplitN(text, sep, n)
 		}
@@ -269,14 +690,84 @@ func renderTemplate(tpl string, data interface{}, includeSprig bool) (string, er
 
 	parsed, err := tmpl.Parse(tpl)
 	if err != nil {
-		return "", fmt.Errorf("parse template: %w", err)
+		return nil, fmt.Errorf("parse template: %w", err)
 	}
+	return parsed, nil
+}
 
-	var buf bytes.Buffer
-	if err := parsed.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("execute template: %w", err)
+// templateCache is an LRU of parsed templates keyed by source text (and
+// whether Sprig helpers and the deterministic wrappers were wired in), so -serve and repeated -stream runs
+// pay template-parsing cost once per distinct source instead of once per
+// case. Safe for concurrent use from the -stream worker pool.
+type templateCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type templateCacheEntry struct {
+	key  string
+	tmpl *texttmpl.Template
+}
+
+// newTemplateCache builds a templateCache holding at most size entries. A
+// non-positive size disables eviction (the cache grows unbounded).
+func newTemplateCache(size int) *templateCache {
+	return &templateCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *templateCache) get(tpl string, includeSprig, deterministic bool) (*texttmpl.Template, error) {
+	key := templateCacheKey(tpl, includeSprig, deterministic)
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		tmpl := el.Value.(*templateCacheEntry).tmpl
+		c.mu.Unlock()
+		return tmpl, nil
+	}
+	c.mu.Unlock()
+
+	tmpl, err := buildTemplate(tpl, includeSprig, deterministic)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*templateCacheEntry).tmpl, nil
+	}
+	el := c.order.PushFront(&templateCacheEntry{key: key, tmpl: tmpl})
+	c.entries[key] = el
+	if c.size > 0 {
+		for c.order.Len() > c.size {
+			oldest := c.order.Back()
+			if oldest == nil {
+				break
+			}
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*templateCacheEntry).key)
+		}
+	}
+	return tmpl, nil
+}
+
+func templateCacheKey(tpl string, includeSprig, deterministic bool) string {
+	switch {
+	case includeSprig && deterministic:
+		return "sprig+deterministic\x00" + tpl
+	case includeSprig:
+		return "sprig\x00" + tpl
+	default:
+		return "plain\x00" + tpl
 	}
-	return buf.String(), nil
 }
 
 func prepareArgs(args []interface{}, fnType reflect.Type) ([]reflect.Value, error) {
@@ -307,15 +798,24 @@ func targetArgumentType(fnType reflect.Type, index int) reflect.Type {
 
 type coercionStrategy func(arg interface{}, targetType reflect.Type) (reflect.Value, bool, error)
 
-var strategies = []coercionStrategy{
-	coerceNilArg,
-	coerceInterfaceArg,
-	coerceNumberArg,
-	coercePrimitiveArg,
+// coercionStrategies is built lazily rather than as a package-level var:
+// several strategies (coerceMapArg, coerceSliceArg) recurse back through
+// coerceArgument, and a var initializer that's reachable from its own
+// initializer (even transitively, through a function body) is an
+// initialization cycle the compiler rejects.
+func coercionStrategies() []coercionStrategy {
+	return []coercionStrategy{
+		coerceNilArg,
+		coerceNumberArg,
+		coerceInterfaceArg,
+		coerceMapArg,
+		coerceSliceArg,
+		coercePrimitiveArg,
+	}
 }
 
 func coerceArgument(arg interface{}, targetType reflect.Type) (reflect.Value, error) {
-	for _, strategy := range strategies {
+	for _, strategy := range coercionStrategies() {
 		if val, handled, err := strategy(arg, targetType); handled {
 			if err != nil {
 				return reflect.Value{}, err
@@ -365,6 +865,83 @@ func coercePrimitiveArg(arg interface{}, targetType reflect.Type) (reflect.Value
 	return reflect.Value{}, false, nil
 }
 
+// coerceSliceArg handles decoded JSON arrays (`[]interface{}`) destined for a
+// slice-typed parameter, such as Sprig's `list`, `concat`, `uniq`, and
+// `without`. Each element is coerced independently so mixed-precision
+// numbers and nested shapes still go through the rest of the strategy chain.
+func coerceSliceArg(arg interface{}, targetType reflect.Type) (reflect.Value, bool, error) {
+	if targetType.Kind() != reflect.Slice {
+		return reflect.Value{}, false, nil
+	}
+	source := reflect.ValueOf(arg)
+	if !source.IsValid() || source.Kind() != reflect.Slice {
+		return reflect.Value{}, false, nil
+	}
+
+	elemType := targetType.Elem()
+	out := reflect.MakeSlice(targetType, source.Len(), source.Len())
+	for i := 0; i < source.Len(); i++ {
+		val, err := coerceArgument(source.Index(i).Interface(), elemType)
+		if err != nil {
+			return reflect.Value{}, true, fmt.Errorf("element %d: %w", i, err)
+		}
+		out.Index(i).Set(val)
+	}
+	return out, true, nil
+}
+
+// coerceMapArg handles decoded JSON objects (`map[string]interface{}`)
+// destined for a map-typed parameter (`dict`, `merge`, `set`, `pick`, `omit`,
+// `has`, `dig`, ...) or, when the JSON fixture represents a concrete struct
+// parameter, falls back to round-tripping through encoding/json so the
+// struct's own field tags and types drive the conversion, mirroring how
+// text/template resolves pipeline arguments against struct fields.
+func coerceMapArg(arg interface{}, targetType reflect.Type) (reflect.Value, bool, error) {
+	switch targetType.Kind() {
+	case reflect.Map:
+		source, ok := arg.(map[string]interface{})
+		if !ok {
+			return reflect.Value{}, false, nil
+		}
+		keyType := targetType.Key()
+		elemType := targetType.Elem()
+		out := reflect.MakeMapWithSize(targetType, len(source))
+		for k, v := range source {
+			key, err := coerceArgument(k, keyType)
+			if err != nil {
+				return reflect.Value{}, true, fmt.Errorf("key %q: %w", k, err)
+			}
+			val, err := coerceArgument(v, elemType)
+			if err != nil {
+				return reflect.Value{}, true, fmt.Errorf("value for key %q: %w", k, err)
+			}
+			out.SetMapIndex(key, val)
+		}
+		return out, true, nil
+	case reflect.Struct:
+		if _, ok := arg.(map[string]interface{}); !ok {
+			return reflect.Value{}, false, nil
+		}
+		return coerceStructArg(arg, targetType)
+	}
+	return reflect.Value{}, false, nil
+}
+
+func coerceStructArg(arg interface{}, targetType reflect.Type) (reflect.Value, bool, error) {
+	encoded, err := json.Marshal(arg)
+	if err != nil {
+		return reflect.Value{}, true, fmt.Errorf("marshal struct argument: %w", err)
+	}
+
+	dest := reflect.New(targetType)
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(dest.Interface()); err != nil {
+		return reflect.Value{}, true, fmt.Errorf("unmarshal into %s: %w", targetType.String(), err)
+	}
+	return dest.Elem(), true, nil
+}
+
 func convertPrimitive(original reflect.Value, targetType reflect.Type) (reflect.Value, bool) {
 	if original.Type().AssignableTo(targetType) {
 		return original, true
@@ -387,6 +964,8 @@ func convertNumber(num json.Number, targetType reflect.Type) (reflect.Value, err
 
 func convertFloat64(f float64, targetType reflect.Type) (reflect.Value, error) {
 	switch targetType.Kind() {
+	case reflect.Interface:
+		return reflect.ValueOf(f), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return convertInt64(int64(f), targetType)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -401,6 +980,8 @@ func convertFloat64(f float64, targetType reflect.Type) (reflect.Value, error) {
 
 func convertInt64(i int64, targetType reflect.Type) (reflect.Value, error) {
 	switch targetType.Kind() {
+	case reflect.Interface:
+		return reflect.ValueOf(i), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		val := reflect.New(targetType).Elem()
 		val.SetInt(i)
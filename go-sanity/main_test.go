@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io"
 	"reflect"
+	"runtime"
 	"testing"
+
+	"github.com/Masterminds/sprig/v3"
 )
 
 func TestCoerceNumberArgJSONInt(t *testing.T) {
@@ -56,3 +62,352 @@ func TestConvertPrimitiveNotConvertible(t *testing.T) {
 		t.Fatalf("expected conversion to fail for incompatible types")
 	}
 }
+
+func TestEvaluateDictWithSprig(t *testing.T) {
+	funcs := sprig.GenericFuncMap()
+	out, err := evaluate(funcs, "dict", []interface{}{"a", json.Number("1"), "b", "two"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, ok := out.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map[string]interface{}, got %T", out)
+	}
+	if got["a"] != int64(1) {
+		t.Fatalf("expected json.Number(\"1\") to normalize to int64(1), got %#v", got["a"])
+	}
+	if got["b"] != "two" {
+		t.Fatalf("expected \"two\", got %#v", got["b"])
+	}
+}
+
+func TestEvaluatePluckWithMapArgs(t *testing.T) {
+	funcs := sprig.GenericFuncMap()
+	args := []interface{}{
+		"count",
+		map[string]interface{}{"count": json.Number("3")},
+		map[string]interface{}{"other": "skip"},
+	}
+	out, err := evaluate(funcs, "pluck", args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, ok := out.([]interface{})
+	if !ok {
+		t.Fatalf("expected []interface{}, got %T", out)
+	}
+	if len(got) != 1 || got[0] != int64(3) {
+		t.Fatalf("expected [int64(3)], got %#v", got)
+	}
+}
+
+func TestNormalizeTemplateDataLargeInt(t *testing.T) {
+	out := normalizeTemplateData(json.Number("9007199254740993"), false) // 2^53 + 1
+	i64, ok := out.(int64)
+	if !ok {
+		t.Fatalf("expected int64, got %T", out)
+	}
+	if i64 != 9007199254740993 {
+		t.Fatalf("expected 9007199254740993, got %d", i64)
+	}
+}
+
+func TestNormalizeTemplateDataNegativeInt(t *testing.T) {
+	out := normalizeTemplateData(json.Number("-42"), false)
+	i64, ok := out.(int64)
+	if !ok || i64 != -42 {
+		t.Fatalf("expected int64(-42), got %#v", out)
+	}
+}
+
+func TestNormalizeTemplateDataDecimal(t *testing.T) {
+	out := normalizeTemplateData(json.Number("1.5"), false)
+	f64, ok := out.(float64)
+	if !ok || f64 != 1.5 {
+		t.Fatalf("expected float64(1.5), got %#v", out)
+	}
+}
+
+func TestNormalizeTemplateDataPreserveNumbers(t *testing.T) {
+	out := normalizeTemplateData(json.Number("9007199254740993"), true)
+	str, ok := out.(string)
+	if !ok {
+		t.Fatalf("expected a plain string to pass through unchanged, got %T", out)
+	}
+	if str != "9007199254740993" {
+		t.Fatalf("expected the raw literal to round-trip, got %q", str)
+	}
+}
+
+// TestRenderTemplatePreserveNumbersSprigMath exercises -preserve-numbers
+// through real Sprig numeric helpers end-to-end. The preserved value is a
+// plain string, not a wrapper type with numeric methods: spf13/cast (which
+// backs add1/add) parses a plain string via strconv regardless of version,
+// so reducing the preserved value to its string literal rather than leaving
+// it as json.Number (which cast only special-cases from v1.4.0) is what
+// keeps 2^53+1 exact through the helper on the cast version sprig v3.2.3
+// actually pins.
+func TestRenderTemplatePreserveNumbersSprigMath(t *testing.T) {
+	tests := []struct {
+		name     string
+		tpl      string
+		data     map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "add1 on a value beyond float64's exact integer range",
+			tpl:      "{{ .count | add1 }}",
+			data:     map[string]interface{}{"count": json.Number("9007199254740993")}, // 2^53 + 1
+			expected: "9007199254740994",
+		},
+		{
+			name:     "add across two preserved values",
+			tpl:      "{{ add .a .b }}",
+			data:     map[string]interface{}{"a": json.Number("9007199254740993"), "b": json.Number("1")},
+			expected: "9007199254740994",
+		},
+	}
+
+	for _, tt := range tests {
+		cache := newTemplateCache(256)
+		rendered, err := renderTemplate(cache, tt.tpl, tt.data, true, true, true)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if rendered != tt.expected {
+			t.Fatalf("%s: expected %q, got %q", tt.name, tt.expected, rendered)
+		}
+	}
+}
+
+func TestNormalizeTemplateDataNested(t *testing.T) {
+	out := normalizeTemplateData(map[string]interface{}{
+		"count": json.Number("3"),
+		"items": []interface{}{json.Number("1"), json.Number("2")},
+	}, false)
+	m, ok := out.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map[string]interface{}, got %T", out)
+	}
+	if m["count"] != int64(3) {
+		t.Fatalf("expected count to normalize to int64(3), got %#v", m["count"])
+	}
+	items, ok := m["items"].([]interface{})
+	if !ok || len(items) != 2 || items[0] != int64(1) || items[1] != int64(2) {
+		t.Fatalf("expected normalized items, got %#v", m["items"])
+	}
+}
+
+func TestValidateDataDetectsCycle(t *testing.T) {
+	m := map[string]interface{}{}
+	m["self"] = m
+	if err := validateData(m, 10000); err == nil {
+		t.Fatalf("expected cycle to be detected")
+	}
+}
+
+func TestValidateDataDetectsExcessiveDepth(t *testing.T) {
+	var nested interface{} = "leaf"
+	for i := 0; i < 20; i++ {
+		nested = []interface{}{nested}
+	}
+	if err := validateData(nested, 10); err == nil {
+		t.Fatalf("expected max depth violation for 20-deep nesting with maxDepth 10")
+	}
+	if err := validateData(nested, 20); err != nil {
+		t.Fatalf("expected 20-deep nesting to pass maxDepth 20: %v", err)
+	}
+}
+
+func TestValidateDataAllowsSharedNonCyclicValue(t *testing.T) {
+	shared := map[string]interface{}{"v": 1}
+	data := map[string]interface{}{"a": shared, "b": shared}
+	if err := validateData(data, 10000); err != nil {
+		t.Fatalf("expected shared-but-acyclic value to pass, got %v", err)
+	}
+}
+
+func TestRunStreamPreservesOrder(t *testing.T) {
+	funcs := sprig.GenericFuncMap()
+	cases := make([]testCase, 50)
+	for i := range cases {
+		cases[i] = testCase{Name: fmt.Sprintf("case-%d", i), Function: "trim", Args: []interface{}{" x "}}
+	}
+
+	var buf bytes.Buffer
+	if err := runStream(&buf, funcs, true, false, 10000, 4, cases, true, newTemplateCache(256)); err != nil {
+		t.Fatalf("runStream failed: %v", err)
+	}
+
+	var results []result
+	if err := json.Unmarshal(buf.Bytes(), &results); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(results) != len(cases) {
+		t.Fatalf("expected %d results, got %d", len(cases), len(results))
+	}
+	for i, res := range results {
+		if res.Name != fmt.Sprintf("case-%d", i) {
+			t.Fatalf("expected results in input order, got %q at index %d", res.Name, i)
+		}
+	}
+}
+
+func TestServeMatchesBatchOutput(t *testing.T) {
+	funcs := sprig.GenericFuncMap()
+	cases := []testCase{
+		{Name: "trim-one", Function: "trim", Args: []interface{}{"  x  "}},
+		{Name: "tmpl-one", Template: "{{ .n }}", Data: map[string]interface{}{"n": json.Number("3")}},
+	}
+
+	cache := newTemplateCache(256)
+	var batchOut bytes.Buffer
+	if err := runBatch(&batchOut, funcs, true, false, 10000, cases, true, cache); err != nil {
+		t.Fatalf("runBatch failed: %v", err)
+	}
+	var wantResults []result
+	if err := json.Unmarshal(batchOut.Bytes(), &wantResults); err != nil {
+		t.Fatalf("invalid batch JSON: %v", err)
+	}
+
+	requestLine, err := json.Marshal(cases)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	requestLine = append(requestLine, '\n')
+
+	var serveOut bytes.Buffer
+	if err := serve(bytes.NewReader(requestLine), &serveOut, funcs, true, false, 10000, true, newTemplateCache(256)); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	var gotResults []result
+	if err := json.Unmarshal(bytes.TrimSpace(serveOut.Bytes()), &gotResults); err != nil {
+		t.Fatalf("invalid serve JSON: %v", err)
+	}
+
+	if !reflect.DeepEqual(wantResults, gotResults) {
+		t.Fatalf("serve output differs from batch output:\nbatch: %#v\nserve: %#v", wantResults, gotResults)
+	}
+}
+
+func TestServeSingleCaseFrame(t *testing.T) {
+	funcs := sprig.GenericFuncMap()
+	line := []byte(`{"name":"one","function":"trim","args":["  y  "]}` + "\n")
+
+	var out bytes.Buffer
+	if err := serve(bytes.NewReader(line), &out, funcs, true, false, 10000, true, newTemplateCache(256)); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	var got result
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &got); err != nil {
+		t.Fatalf("invalid JSON response: %v", err)
+	}
+	if got.Output != "y" {
+		t.Fatalf("expected trimmed output \"y\", got %#v", got.Output)
+	}
+}
+
+func TestWrapDeterministicMapFunctionsKeys(t *testing.T) {
+	funcsReversed := sprig.GenericFuncMap()
+	wrapDeterministicMapFunctions(funcsReversed)
+	keysReversed := funcsReversed["keys"].(func(...map[string]interface{}) []string)
+	gotReversed := keysReversed(map[string]interface{}{"b": 2, "a": 1})
+
+	funcsForward := sprig.GenericFuncMap()
+	wrapDeterministicMapFunctions(funcsForward)
+	keysForward := funcsForward["keys"].(func(...map[string]interface{}) []string)
+	gotForward := keysForward(map[string]interface{}{"a": 1, "b": 2})
+
+	want := []string{"a", "b"}
+	if !reflect.DeepEqual(gotReversed, want) || !reflect.DeepEqual(gotForward, want) {
+		t.Fatalf("expected sorted keys regardless of literal order, got %#v and %#v", gotReversed, gotForward)
+	}
+}
+
+func TestWrapDeterministicMapFunctionsValues(t *testing.T) {
+	funcsReversed := sprig.GenericFuncMap()
+	wrapDeterministicMapFunctions(funcsReversed)
+	valuesReversed := funcsReversed["values"].(func(map[string]interface{}) []interface{})
+	gotReversed := valuesReversed(map[string]interface{}{"b": 2, "a": 1})
+
+	funcsForward := sprig.GenericFuncMap()
+	wrapDeterministicMapFunctions(funcsForward)
+	valuesForward := funcsForward["values"].(func(map[string]interface{}) []interface{})
+	gotForward := valuesForward(map[string]interface{}{"a": 1, "b": 2})
+
+	want := []interface{}{1, 2}
+	if !reflect.DeepEqual(gotReversed, want) || !reflect.DeepEqual(gotForward, want) {
+		t.Fatalf("expected sorted values regardless of literal order, got %#v and %#v", gotReversed, gotForward)
+	}
+}
+
+func TestRenderTemplateDeterministicKeys(t *testing.T) {
+	cache := newTemplateCache(256)
+	data := map[string]interface{}{"b": 1, "a": 2, "c": 3}
+	rendered, err := renderTemplate(cache, `{{ keys . | join "," }}`, data, true, false, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rendered != "a,b,c" {
+		t.Fatalf("expected deterministically sorted keys \"a,b,c\", got %q", rendered)
+	}
+}
+
+func TestTemplateCacheReusesParsedTemplate(t *testing.T) {
+	cache := newTemplateCache(2)
+	first, err := cache.get("{{ . }}", false, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := cache.get("{{ . }}", false, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != second {
+		t.Fatalf("expected cache hit to return the same *texttmpl.Template")
+	}
+}
+
+func BenchmarkRunLargeFixture(b *testing.B) {
+	const n = 10000
+	funcs := sprig.GenericFuncMap()
+	wrapExtremumFunctions(funcs)
+
+	cases := make([]testCase, n)
+	for i := range cases {
+		cases[i] = testCase{
+			Name:     fmt.Sprintf("case-%d", i),
+			Function: "trim",
+			Args:     []interface{}{"  hello  "},
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := runStream(io.Discard, funcs, true, false, 10000, runtime.NumCPU(), cases, true, newTemplateCache(256)); err != nil {
+			b.Fatalf("runStream failed: %v", err)
+		}
+	}
+}
+
+type coerceTestStruct struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestCoerceMapArgStructFallback(t *testing.T) {
+	arg := map[string]interface{}{"name": "widget", "count": json.Number("7")}
+	val, err := coerceArgument(arg, reflect.TypeOf(coerceTestStruct{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, ok := val.Interface().(coerceTestStruct)
+	if !ok {
+		t.Fatalf("expected coerceTestStruct, got %T", val.Interface())
+	}
+	if got != (coerceTestStruct{Name: "widget", Count: 7}) {
+		t.Fatalf("unexpected struct value: %#v", got)
+	}
+}